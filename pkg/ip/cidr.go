@@ -0,0 +1,147 @@
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package ip
+
+import (
+	"net"
+	"net/netip"
+	"strconv"
+	"strings"
+)
+
+// parseAddrForVersion parses s as an address of the given version, rejecting
+// it if it doesn't belong to that family.
+func parseAddrForVersion(version int64, s string) (netip.Addr, error) {
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Addr{}, err
+	}
+	addr = addr.Unmap()
+	if !addrMatchesVersion(addr, version) {
+		return netip.Addr{}, ErrInvalidIPFormat
+	}
+	return addr, nil
+}
+
+// parsePrefixForVersion parses s as a CIDR of the given version, masking off
+// the host bits (the returned prefix's address is the network address).
+func parsePrefixForVersion(version int64, s string) (netip.Prefix, error) {
+	prefix, err := netip.ParsePrefix(s)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	addr := prefix.Addr().Unmap()
+	if !addrMatchesVersion(addr, version) {
+		return netip.Prefix{}, ErrInvalidCIDRFormat
+	}
+	return netip.PrefixFrom(addr, prefix.Bits()).Masked(), nil
+}
+
+// splitCIDRForVersion parses s as "addr/n" without masking off the host
+// bits of addr, returning the address as-is alongside the prefix length.
+func splitCIDRForVersion(version int64, s string) (netip.Addr, int, error) {
+	idx := strings.LastIndex(s, "/")
+	if idx < 0 {
+		return netip.Addr{}, 0, ErrInvalidCIDRFormat
+	}
+
+	addr, err := parseAddrForVersion(version, s[:idx])
+	if err != nil {
+		return netip.Addr{}, 0, ErrInvalidCIDRFormat
+	}
+
+	prefixLen, err := strconv.Atoi(s[idx+1:])
+	if err != nil || prefixLen < 0 || prefixLen > addrBits(version) {
+		return netip.Addr{}, 0, ErrInvalidCIDRFormat
+	}
+
+	return addr, prefixLen, nil
+}
+
+// ParseCIDR parses cidr as a network of the given version, returning the
+// masked network address and mask, e.g. ParseCIDR(IPv4, "172.18.40.40/24")
+// yields 172.18.40.0/24.
+func ParseCIDR(version int64, cidr string) (*net.IPNet, error) {
+	if err := IsIPVersion(version); err != nil {
+		return nil, err
+	}
+
+	prefix, err := parsePrefixForVersion(version, cidr)
+	if err != nil {
+		return nil, ErrInvalidCIDRFormat
+	}
+
+	bits := addrBits(version)
+	ipNet := &net.IPNet{
+		IP:   netIPFromAddr(prefix.Addr()),
+		Mask: net.CIDRMask(prefix.Bits(), bits),
+	}
+	if version == 4 {
+		ipNet.IP = ipNet.IP.To4()
+	}
+	return ipNet, nil
+}
+
+// ContainsCIDR reports whether subnetA fully contains subnetB.
+func ContainsCIDR(version int64, subnetA, subnetB string) (bool, error) {
+	if err := IsIPVersion(version); err != nil {
+		return false, err
+	}
+
+	prefixA, err := parsePrefixForVersion(version, subnetA)
+	if err != nil {
+		return false, ErrInvalidCIDRFormat
+	}
+
+	prefixB, err := parsePrefixForVersion(version, subnetB)
+	if err != nil {
+		return false, ErrInvalidCIDRFormat
+	}
+
+	return prefixA.Bits() <= prefixB.Bits() && prefixA.Contains(prefixB.Addr()), nil
+}
+
+// IsCIDROverlap reports whether subnetA and subnetB share at least one
+// address.
+func IsCIDROverlap(version int64, subnetA, subnetB string) (bool, error) {
+	if err := IsIPVersion(version); err != nil {
+		return false, err
+	}
+
+	prefixA, err := parsePrefixForVersion(version, subnetA)
+	if err != nil {
+		return false, ErrInvalidCIDRFormat
+	}
+
+	prefixB, err := parsePrefixForVersion(version, subnetB)
+	if err != nil {
+		return false, ErrInvalidCIDRFormat
+	}
+
+	return prefixA.Overlaps(prefixB), nil
+}
+
+// IsCIDR checks whether cidr is a valid network of the given version.
+func IsCIDR(version int64, cidr string) error {
+	if err := IsIPVersion(version); err != nil {
+		return err
+	}
+
+	if _, err := parsePrefixForVersion(version, cidr); err != nil {
+		return ErrInvalidCIDRFormat
+	}
+	return nil
+}
+
+// IsIPv4CIDR reports whether cidr is a valid IPv4 network.
+func IsIPv4CIDR(cidr string) bool {
+	prefix, err := netip.ParsePrefix(cidr)
+	return err == nil && prefix.Addr().Is4()
+}
+
+// IsIPv6CIDR reports whether cidr is a valid IPv6 network.
+func IsIPv6CIDR(cidr string) bool {
+	prefix, err := netip.ParsePrefix(cidr)
+	return err == nil && prefix.Addr().Is6() && !prefix.Addr().Is4In6()
+}
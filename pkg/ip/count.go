@@ -0,0 +1,59 @@
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package ip
+
+import (
+	"math/big"
+	"net/netip"
+)
+
+// AddressCount returns the total number of addresses covered by prefix,
+// i.e. 2^(bits-prefixLen). It is backed by big.Int so that IPv6 prefixes
+// wider than /64 don't overflow a machine word.
+func AddressCount(prefix netip.Prefix) *big.Int {
+	hostBits := prefix.Addr().BitLen() - prefix.Bits()
+	return new(big.Int).Lsh(big.NewInt(1), uint(hostBits))
+}
+
+// UsableHostCount returns the number of addresses in prefix that can
+// actually be assigned to a workload: for IPv4 prefixes shorter than /31,
+// the network and broadcast addresses are subtracted; /31 and /32 (and
+// every IPv6 prefix) keep their full AddressCount, since IPv6 has no
+// broadcast address and /31-/32 have no spare network/broadcast pair to
+// reclaim.
+func UsableHostCount(prefix netip.Prefix) *big.Int {
+	count := AddressCount(prefix)
+
+	addr := prefix.Addr().Unmap()
+	if addr.Is4() && addr.BitLen()-prefix.Bits() >= 2 {
+		count = new(big.Int).Sub(count, big.NewInt(2))
+	}
+	return count
+}
+
+// CountMode selects whether SpiderIPPool/SpiderSubnet status fields report
+// the raw AddressCount or the UsableHostCount of a pool's ranges.
+//
+// Known gap: nothing wires CountMode up to a CRD field or a --count-mode
+// flag yet, and .status.totalIPCount/.status.allocatedIPCount don't call
+// Count - the SpiderIPPool/SpiderSubnet controllers and their CRD types
+// live outside this tree snapshot. This type and Count exist so that
+// wiring has a single, tested place to plug into.
+type CountMode string
+
+const (
+	CountModeRaw    CountMode = "raw"
+	CountModeUsable CountMode = "usable"
+)
+
+// Count returns AddressCount or UsableHostCount for prefix according to
+// mode, defaulting to UsableHostCount for an unrecognized mode so that
+// .status.totalIPCount keeps reporting assignable addresses rather than
+// silently falling back to raw sizing.
+func Count(prefix netip.Prefix, mode CountMode) *big.Int {
+	if mode == CountModeRaw {
+		return AddressCount(prefix)
+	}
+	return UsableHostCount(prefix)
+}
@@ -0,0 +1,62 @@
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package ip_test
+
+import (
+	"math/big"
+	"net/netip"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	spiderpoolip "github.com/spidernet-io/spiderpool/pkg/ip"
+)
+
+var _ = Describe("Count", Label("count_test"), func() {
+	Describe("Test AddressCount", func() {
+		It("counts every address in an IPv4 prefix", func() {
+			count := spiderpoolip.AddressCount(netip.MustParsePrefix("172.18.40.0/24"))
+			Expect(count.Int64()).To(Equal(int64(256)))
+		})
+
+		It("counts every address in a wide IPv6 prefix without overflowing", func() {
+			count := spiderpoolip.AddressCount(netip.MustParsePrefix("abcd:1234::/64"))
+			Expect(count.String()).To(Equal(new(big.Int).Lsh(big.NewInt(1), 64).String()))
+		})
+	})
+
+	Describe("Test UsableHostCount", func() {
+		It("subtracts network and broadcast for an IPv4 prefix shorter than /31", func() {
+			count := spiderpoolip.UsableHostCount(netip.MustParsePrefix("172.18.40.0/24"))
+			Expect(count.Int64()).To(Equal(int64(254)))
+		})
+
+		It("subtracts nothing for an IPv4 /31", func() {
+			count := spiderpoolip.UsableHostCount(netip.MustParsePrefix("172.18.40.0/31"))
+			Expect(count.Int64()).To(Equal(int64(2)))
+		})
+
+		It("subtracts nothing for an IPv4 /32", func() {
+			count := spiderpoolip.UsableHostCount(netip.MustParsePrefix("172.18.40.40/32"))
+			Expect(count.Int64()).To(Equal(int64(1)))
+		})
+
+		It("subtracts nothing for IPv6", func() {
+			count := spiderpoolip.UsableHostCount(netip.MustParsePrefix("abcd:1234::/120"))
+			Expect(count.Int64()).To(Equal(int64(256)))
+		})
+	})
+
+	Describe("Test Count", func() {
+		It("honors CountModeRaw", func() {
+			count := spiderpoolip.Count(netip.MustParsePrefix("172.18.40.0/24"), spiderpoolip.CountModeRaw)
+			Expect(count.Int64()).To(Equal(int64(256)))
+		})
+
+		It("honors CountModeUsable", func() {
+			count := spiderpoolip.Count(netip.MustParsePrefix("172.18.40.0/24"), spiderpoolip.CountModeUsable)
+			Expect(count.Int64()).To(Equal(int64(254)))
+		})
+	})
+})
@@ -0,0 +1,16 @@
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package ip
+
+import "errors"
+
+var (
+	ErrInvalidIPVersion  = errors.New("invalid IP version")
+	ErrInvalidIPFormat   = errors.New("invalid IP format")
+	ErrInvalidCIDRFormat = errors.New("invalid CIDR format")
+
+	ErrInvalidIPRangeFormat = errors.New("invalid IP range format")
+	ErrInvalidIPRangeOrder  = errors.New("IP range start is greater than its end")
+	ErrMismatchedIPFamily   = errors.New("mismatched IP family")
+)
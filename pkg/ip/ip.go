@@ -0,0 +1,196 @@
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+// Package ip provides IP/CIDR parsing, validation and set-algebra helpers
+// for Spiderpool. The public API still speaks net.IP/net.IPNet so that
+// callers (CRD types, webhooks, the IPAM allocator) don't need to change,
+// but every operation is implemented on top of net/netip internally: netip
+// addresses are comparable values and are an order of magnitude cheaper to
+// parse and compare than net.IP's byte-slice representation.
+package ip
+
+import (
+	"net"
+	"net/netip"
+
+	"github.com/spidernet-io/spiderpool/pkg/constant"
+)
+
+// IsIPVersion checks whether version is a supported IP version.
+func IsIPVersion(version int64) error {
+	if version != constant.IPv4 && version != constant.IPv6 {
+		return ErrInvalidIPVersion
+	}
+	return nil
+}
+
+// ParseIP parses ip as an address of the given version. When isCIDR is true,
+// ip is expected in "a.b.c.d/n" (or "addr/n" for IPv6) form and the returned
+// net.IPNet keeps the host bits of the address intact, only the mask is
+// derived from the prefix length. When isCIDR is false, ip is a bare address
+// and the returned net.IPNet carries a full-length mask (/32 or /128).
+func ParseIP(version int64, ip string, isCIDR bool) (*net.IPNet, error) {
+	if err := IsIPVersion(version); err != nil {
+		return nil, err
+	}
+
+	if isCIDR {
+		return parseIPWithCIDRFormat(version, ip)
+	}
+	return parseIPWithPlainFormat(version, ip)
+}
+
+func parseIPWithPlainFormat(version int64, ip string) (*net.IPNet, error) {
+	addr, err := parseAddrForVersion(version, ip)
+	if err != nil {
+		return nil, ErrInvalidIPFormat
+	}
+
+	bits := addrBits(version)
+	return &net.IPNet{
+		IP:   netIPFromAddr(addr),
+		Mask: net.CIDRMask(bits, bits),
+	}, nil
+}
+
+func parseIPWithCIDRFormat(version int64, cidr string) (*net.IPNet, error) {
+	addr, prefixLen, err := splitCIDRForVersion(version, cidr)
+	if err != nil {
+		return nil, ErrInvalidCIDRFormat
+	}
+
+	bits := addrBits(version)
+	return &net.IPNet{
+		IP:   netIPFromAddr(addr),
+		Mask: net.CIDRMask(prefixLen, bits),
+	}, nil
+}
+
+// IsIP checks whether ip is a valid address of the given version.
+func IsIP(version int64, ip string) error {
+	if err := IsIPVersion(version); err != nil {
+		return err
+	}
+
+	if _, err := parseAddrForVersion(version, ip); err != nil {
+		return ErrInvalidIPFormat
+	}
+	return nil
+}
+
+// ContainsIP reports whether the CIDR subnet contains ip.
+func ContainsIP(version int64, subnet, ip string) (bool, error) {
+	if err := IsIPVersion(version); err != nil {
+		return false, err
+	}
+
+	prefix, err := parsePrefixForVersion(version, subnet)
+	if err != nil {
+		return false, ErrInvalidCIDRFormat
+	}
+
+	addr, err := parseAddrForVersion(version, ip)
+	if err != nil {
+		return false, ErrInvalidIPFormat
+	}
+
+	return prefix.Contains(addr), nil
+}
+
+// Cmp compares ipA and ipB, returning a negative number, zero or a positive
+// number as ipA is numerically less than, equal to, or greater than ipB.
+// It is agnostic to whether the inputs are in 4-byte or 16-byte form.
+func Cmp(ipA, ipB net.IP) int {
+	addrA, _ := addrFromNetIP(ipA)
+	addrB, _ := addrFromNetIP(ipB)
+	return addrA.Compare(addrB)
+}
+
+// NextIP returns the IP address that immediately follows ip.
+func NextIP(ip net.IP) net.IP {
+	addr, ok := addrFromNetIP(ip)
+	if !ok {
+		return nil
+	}
+	return netIPFromAddr(addr.Next())
+}
+
+// PrevIP returns the IP address that immediately precedes ip.
+func PrevIP(ip net.IP) net.IP {
+	addr, ok := addrFromNetIP(ip)
+	if !ok {
+		return nil
+	}
+	return netIPFromAddr(addr.Prev())
+}
+
+// IPsDiffSet returns the IPs in ipsA that are not present in ipsB.
+func IPsDiffSet(ipsA, ipsB []net.IP) []net.IP {
+	inB := indexIPs(ipsB)
+
+	diff := make([]net.IP, 0, len(ipsA))
+	for _, ip := range ipsA {
+		if addr, ok := addrFromNetIP(ip); ok {
+			if _, ok := inB[addr]; ok {
+				continue
+			}
+		}
+		diff = append(diff, ip)
+	}
+	return diff
+}
+
+// IPsUnionSet returns the deduplicated union of ipsA and ipsB, preserving
+// the order in which each address was first seen.
+func IPsUnionSet(ipsA, ipsB []net.IP) []net.IP {
+	seen := make(map[netip.Addr]struct{}, len(ipsA)+len(ipsB))
+	union := make([]net.IP, 0, len(ipsA)+len(ipsB))
+
+	appendUnseen := func(ips []net.IP) {
+		for _, ip := range ips {
+			addr, ok := addrFromNetIP(ip)
+			if !ok {
+				union = append(union, ip)
+				continue
+			}
+			if _, ok := seen[addr]; ok {
+				continue
+			}
+			seen[addr] = struct{}{}
+			union = append(union, ip)
+		}
+	}
+
+	appendUnseen(ipsA)
+	appendUnseen(ipsB)
+	return union
+}
+
+// indexIPs builds a lookup set of the given IPs keyed by their
+// representation-agnostic netip.Addr form.
+func indexIPs(ips []net.IP) map[netip.Addr]struct{} {
+	index := make(map[netip.Addr]struct{}, len(ips))
+	for _, ip := range ips {
+		if addr, ok := addrFromNetIP(ip); ok {
+			index[addr] = struct{}{}
+		}
+	}
+	return index
+}
+
+// IPsIntersectionSet returns the IPs in ipsA that are also present in ipsB.
+func IPsIntersectionSet(ipsA, ipsB []net.IP) []net.IP {
+	inB := indexIPs(ipsB)
+
+	intersection := make([]net.IP, 0, len(ipsA))
+	for _, ip := range ipsA {
+		addr, ok := addrFromNetIP(ip)
+		if !ok {
+			continue
+		}
+		if _, ok := inB[addr]; ok {
+			intersection = append(intersection, ip)
+		}
+	}
+	return intersection
+}
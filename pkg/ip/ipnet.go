@@ -0,0 +1,20 @@
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package ip
+
+import "net"
+
+// IPNetEqual reports whether a and b describe the same network: same
+// address, independent of whether it is stored in its 4-byte or 16-byte
+// form, and masks with the same number of ones and the same total bits. A
+// 4-byte IPv4 mask and a 16-byte one are therefore not equal.
+func IPNetEqual(a, b *net.IPNet) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	aOnes, aBits := a.Mask.Size()
+	bOnes, bBits := b.Mask.Size()
+	return a.IP.Equal(b.IP) && aOnes == bOnes && aBits == bBits
+}
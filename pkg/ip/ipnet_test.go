@@ -0,0 +1,33 @@
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package ip_test
+
+import (
+	"net"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	spiderpoolip "github.com/spidernet-io/spiderpool/pkg/ip"
+)
+
+var _ = Describe("IPNetEqual", Label("ipnet_test"), func() {
+	It("treats the 4-byte and 16-byte forms of the same network as equal", func() {
+		a := &net.IPNet{IP: net.IPv4(172, 18, 40, 0).To4(), Mask: net.CIDRMask(24, 32)}
+		b := &net.IPNet{IP: net.IPv4(172, 18, 40, 0), Mask: net.CIDRMask(24, 32)}
+		Expect(spiderpoolip.IPNetEqual(a, b)).To(BeTrue())
+	})
+
+	It("reports networks with different mask lengths as unequal", func() {
+		a := &net.IPNet{IP: net.IPv4(172, 18, 40, 0).To4(), Mask: net.CIDRMask(24, 32)}
+		b := &net.IPNet{IP: net.IPv4(172, 18, 40, 0).To4(), Mask: net.CIDRMask(25, 32)}
+		Expect(spiderpoolip.IPNetEqual(a, b)).To(BeFalse())
+	})
+
+	It("treats nil the same as itself but not as a non-nil network", func() {
+		var nilNet *net.IPNet
+		Expect(spiderpoolip.IPNetEqual(nilNet, nilNet)).To(BeTrue())
+		Expect(spiderpoolip.IPNetEqual(nilNet, &net.IPNet{IP: net.IPv4(172, 18, 40, 0), Mask: net.CIDRMask(24, 32)})).To(BeFalse())
+	})
+})
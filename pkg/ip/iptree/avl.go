@@ -0,0 +1,202 @@
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package iptree
+
+import "bytes"
+
+// rangeKey is the 16-byte big-endian (start, end) composite key that orders
+// nodes in the tree. Using the full 16-byte form for both v4 and v6
+// addresses keeps the comparison logic family-agnostic; a Tree only ever
+// mixes keys of one family because Insert rejects the other.
+type rangeKey struct {
+	start [16]byte
+	end   [16]byte
+}
+
+// compare orders keys by start, breaking ties on end so that distinct
+// ranges sharing a start address still get a deterministic, stable
+// position in the tree.
+func (k rangeKey) compare(other rangeKey) int {
+	if c := bytes.Compare(k.start[:], other.start[:]); c != 0 {
+		return c
+	}
+	return bytes.Compare(k.end[:], other.end[:])
+}
+
+type node struct {
+	key    rangeKey
+	entry  Entry
+	maxEnd [16]byte
+	height int
+	left   *node
+	right  *node
+}
+
+func max16(a, b [16]byte) [16]byte {
+	if bytes.Compare(a[:], b[:]) >= 0 {
+		return a
+	}
+	return b
+}
+
+func height(n *node) int {
+	if n == nil {
+		return 0
+	}
+	return n.height
+}
+
+func (n *node) update() {
+	n.height = 1 + maxInt(height(n.left), height(n.right))
+	n.maxEnd = n.key.end
+	if n.left != nil {
+		n.maxEnd = max16(n.maxEnd, n.left.maxEnd)
+	}
+	if n.right != nil {
+		n.maxEnd = max16(n.maxEnd, n.right.maxEnd)
+	}
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func balanceFactor(n *node) int {
+	if n == nil {
+		return 0
+	}
+	return height(n.left) - height(n.right)
+}
+
+func rotateRight(n *node) *node {
+	l := n.left
+	n.left = l.right
+	l.right = n
+	n.update()
+	l.update()
+	return l
+}
+
+func rotateLeft(n *node) *node {
+	r := n.right
+	n.right = r.left
+	r.left = n
+	n.update()
+	r.update()
+	return r
+}
+
+func rebalance(n *node) *node {
+	n.update()
+	bf := balanceFactor(n)
+
+	if bf > 1 {
+		if balanceFactor(n.left) < 0 {
+			n.left = rotateLeft(n.left)
+		}
+		return rotateRight(n)
+	}
+	if bf < -1 {
+		if balanceFactor(n.right) > 0 {
+			n.right = rotateRight(n.right)
+		}
+		return rotateLeft(n)
+	}
+	return n
+}
+
+// insert adds entry to the subtree rooted at n, keeping nodes with an equal
+// key to the right so that ranges sharing the same (start, end) are all
+// retained rather than overwritten.
+func insert(n *node, entry Entry) *node {
+	if n == nil {
+		nn := &node{key: entry.key(), entry: entry}
+		nn.update()
+		return nn
+	}
+
+	if entry.key().compare(n.key) < 0 {
+		n.left = insert(n.left, entry)
+	} else {
+		n.right = insert(n.right, entry)
+	}
+	return rebalance(n)
+}
+
+// remove deletes the node matching entry's name and range from the subtree
+// rooted at n, setting *removed to true if a match was found.
+func remove(n *node, entry Entry, removed *bool) *node {
+	if n == nil {
+		return nil
+	}
+
+	key := entry.key()
+	switch {
+	case key.compare(n.key) < 0:
+		n.left = remove(n.left, entry, removed)
+	case key.compare(n.key) > 0:
+		n.right = remove(n.right, entry, removed)
+	case n.entry.Name != entry.Name:
+		// Same range, different logical entry: keep searching both
+		// sides since duplicates are stored to the right on insert.
+		n.right = remove(n.right, entry, removed)
+		if !*removed {
+			n.left = remove(n.left, entry, removed)
+		}
+	default:
+		*removed = true
+		if n.left == nil {
+			return n.right
+		}
+		if n.right == nil {
+			return n.left
+		}
+		successor := leftmost(n.right)
+		n.entry = successor.entry
+		n.key = successor.key
+		dummy := false
+		n.right = remove(n.right, successor.entry, &dummy)
+	}
+
+	if n == nil {
+		return nil
+	}
+	return rebalance(n)
+}
+
+func leftmost(n *node) *node {
+	for n.left != nil {
+		n = n.left
+	}
+	return n
+}
+
+// overlapsRange reports whether [aStart, aEnd] intersects [bStart, bEnd].
+func overlapsRange(aStart, aEnd, bStart, bEnd [16]byte) bool {
+	return bytes.Compare(aStart[:], bEnd[:]) <= 0 && bytes.Compare(bStart[:], aEnd[:]) <= 0
+}
+
+// searchOverlaps walks the subtree rooted at n, appending every entry whose
+// range intersects [q.start, q.end] to out. It prunes subtrees whose
+// augmented maxEnd proves no contained range can reach q.start.
+func searchOverlaps(n *node, q rangeKey, out *[]Entry) {
+	if n == nil {
+		return
+	}
+
+	if n.left != nil && bytes.Compare(n.left.maxEnd[:], q.start[:]) >= 0 {
+		searchOverlaps(n.left, q, out)
+	}
+
+	if overlapsRange(n.key.start, n.key.end, q.start, q.end) {
+		*out = append(*out, n.entry)
+	}
+
+	if bytes.Compare(n.key.start[:], q.end[:]) <= 0 {
+		searchOverlaps(n.right, q, out)
+	}
+}
@@ -0,0 +1,184 @@
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package iptree_test
+
+import (
+	"fmt"
+	"net/netip"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/spidernet-io/spiderpool/pkg/constant"
+	"github.com/spidernet-io/spiderpool/pkg/ip/iptree"
+)
+
+func entry(name, start, end string) iptree.Entry {
+	return iptree.Entry{
+		Name:  name,
+		Start: netip.MustParseAddr(start),
+		End:   netip.MustParseAddr(end),
+	}
+}
+
+var _ = Describe("Tree", Label("iptree_test"), func() {
+	Describe("Overlaps and Containing", func() {
+		It("finds entries whose range intersects a query range", func() {
+			tree, err := iptree.New(constant.IPv4)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(tree.Insert(entry("pool-a", "172.18.40.0", "172.18.40.255"))).To(Succeed())
+			Expect(tree.Insert(entry("pool-b", "172.18.41.0", "172.18.41.255"))).To(Succeed())
+			Expect(tree.Insert(entry("pool-c", "172.18.42.0", "172.18.43.255"))).To(Succeed())
+
+			found := tree.Overlaps(netip.MustParseAddr("172.18.41.128"), netip.MustParseAddr("172.18.42.10"))
+			names := make([]string, 0, len(found))
+			for _, e := range found {
+				names = append(names, e.Name)
+			}
+			Expect(names).To(ConsistOf("pool-b", "pool-c"))
+
+			found = tree.Containing(netip.MustParseAddr("172.18.40.40"))
+			Expect(found).To(HaveLen(1))
+			Expect(found[0].Name).To(Equal("pool-a"))
+		})
+
+		It("rejects entries from the wrong IP family", func() {
+			tree, err := iptree.New(constant.IPv4)
+			Expect(err).NotTo(HaveOccurred())
+
+			err = tree.Insert(entry("v6-pool", "abcd:1234::1", "abcd:1234::ff"))
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("Delete", func() {
+		It("removes an entry so it no longer overlaps", func() {
+			tree, err := iptree.New(constant.IPv4)
+			Expect(err).NotTo(HaveOccurred())
+
+			e := entry("pool-a", "172.18.40.0", "172.18.40.255")
+			Expect(tree.Insert(e)).To(Succeed())
+			Expect(tree.Delete(e)).To(BeTrue())
+			Expect(tree.Len()).To(Equal(0))
+			Expect(tree.Containing(netip.MustParseAddr("172.18.40.1"))).To(BeEmpty())
+		})
+	})
+
+	Describe("Rotations", func() {
+		It("rebalances and keeps every entry findable under an ascending insert order", func() {
+			tree, err := iptree.New(constant.IPv4)
+			Expect(err).NotTo(HaveOccurred())
+
+			const n = 20
+			for i := 0; i < n; i++ {
+				Expect(tree.Insert(entry(
+					fmt.Sprintf("pool-%02d", i),
+					fmt.Sprintf("10.0.%d.0", i),
+					fmt.Sprintf("10.0.%d.255", i),
+				))).To(Succeed())
+			}
+			Expect(tree.Len()).To(Equal(n))
+
+			for i := 0; i < n; i++ {
+				found := tree.Containing(netip.MustParseAddr(fmt.Sprintf("10.0.%d.1", i)))
+				Expect(found).To(HaveLen(1), "pool-%02d", i)
+				Expect(found[0].Name).To(Equal(fmt.Sprintf("pool-%02d", i)))
+			}
+		})
+
+		It("rebalances and keeps every entry findable under a descending insert order", func() {
+			tree, err := iptree.New(constant.IPv4)
+			Expect(err).NotTo(HaveOccurred())
+
+			const n = 20
+			for i := n - 1; i >= 0; i-- {
+				Expect(tree.Insert(entry(
+					fmt.Sprintf("pool-%02d", i),
+					fmt.Sprintf("10.0.%d.0", i),
+					fmt.Sprintf("10.0.%d.255", i),
+				))).To(Succeed())
+			}
+			Expect(tree.Len()).To(Equal(n))
+
+			for i := 0; i < n; i++ {
+				found := tree.Containing(netip.MustParseAddr(fmt.Sprintf("10.0.%d.1", i)))
+				Expect(found).To(HaveLen(1), "pool-%02d", i)
+				Expect(found[0].Name).To(Equal(fmt.Sprintf("pool-%02d", i)))
+			}
+		})
+
+		It("rebalances and keeps every entry findable under a zig-zag insert order", func() {
+			tree, err := iptree.New(constant.IPv4)
+			Expect(err).NotTo(HaveOccurred())
+
+			// Alternating near the middle and the edges forces the
+			// right-left double-rotation case (inserting 2 under 0 -> 5),
+			// not just the single left/left or right/right ones the purely
+			// ascending/descending orders above trigger. This order does
+			// not trigger the left-right case.
+			order := []int{10, 0, 19, 5, 15, 2, 8, 12, 18, 1, 3, 9, 11, 17, 4, 6, 7, 13, 14, 16}
+			for _, i := range order {
+				Expect(tree.Insert(entry(
+					fmt.Sprintf("pool-%02d", i),
+					fmt.Sprintf("10.0.%d.0", i),
+					fmt.Sprintf("10.0.%d.255", i),
+				))).To(Succeed())
+			}
+			Expect(tree.Len()).To(Equal(len(order)))
+
+			for _, i := range order {
+				found := tree.Containing(netip.MustParseAddr(fmt.Sprintf("10.0.%d.1", i)))
+				Expect(found).To(HaveLen(1), "pool-%02d", i)
+				Expect(found[0].Name).To(Equal(fmt.Sprintf("pool-%02d", i)))
+			}
+		})
+	})
+
+	Describe("Duplicate ranges", func() {
+		It("keeps the other entry findable after one of two identical ranges is deleted by name", func() {
+			tree, err := iptree.New(constant.IPv4)
+			Expect(err).NotTo(HaveOccurred())
+
+			a := entry("dup-a", "172.18.40.0", "172.18.40.255")
+			b := entry("dup-b", "172.18.40.0", "172.18.40.255")
+			Expect(tree.Insert(a)).To(Succeed())
+			Expect(tree.Insert(b)).To(Succeed())
+			Expect(tree.Len()).To(Equal(2))
+
+			Expect(tree.Delete(a)).To(BeTrue())
+			Expect(tree.Len()).To(Equal(1))
+
+			found := tree.Containing(netip.MustParseAddr("172.18.40.10"))
+			Expect(found).To(HaveLen(1))
+			Expect(found[0].Name).To(Equal("dup-b"))
+
+			overlapping := tree.Overlaps(netip.MustParseAddr("172.18.40.0"), netip.MustParseAddr("172.18.40.255"))
+			Expect(overlapping).To(HaveLen(1))
+			Expect(overlapping[0].Name).To(Equal("dup-b"))
+		})
+	})
+
+	Describe("Validate", func() {
+		It("reports every pairwise overlap across a batch of pools", func() {
+			conflicts := iptree.Validate([]iptree.Entry{
+				entry("pool-a", "172.18.40.0", "172.18.40.255"),
+				entry("pool-b", "172.18.40.128", "172.18.41.255"),
+				entry("pool-c", "172.18.50.0", "172.18.50.255"),
+			})
+
+			Expect(conflicts).To(HaveLen(1))
+			Expect(conflicts[0].A.Name).To(Equal("pool-a"))
+			Expect(conflicts[0].B.Name).To(Equal("pool-b"))
+		})
+
+		It("reports no conflicts for disjoint pools", func() {
+			conflicts := iptree.Validate([]iptree.Entry{
+				entry("pool-a", "172.18.40.0", "172.18.40.255"),
+				entry("pool-b", "172.18.41.0", "172.18.41.255"),
+			})
+			Expect(conflicts).To(BeEmpty())
+		})
+	})
+})
@@ -0,0 +1,96 @@
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package ip
+
+import (
+	"fmt"
+	"net/netip"
+	"strings"
+)
+
+// IPRange is an inclusive, contiguous span of addresses of a single IP
+// family, e.g. the usable range of a SpiderIPPool.
+type IPRange struct {
+	Start netip.Addr
+	End   netip.Addr
+}
+
+// NewIPRange builds an IPRange from start and end, rejecting mismatched
+// families or an end that comes before start.
+func NewIPRange(start, end netip.Addr) (IPRange, error) {
+	start, end = start.Unmap(), end.Unmap()
+	if !start.IsValid() || !end.IsValid() {
+		return IPRange{}, ErrInvalidIPRangeFormat
+	}
+	if start.Is4() != end.Is4() {
+		return IPRange{}, ErrMismatchedIPFamily
+	}
+	if start.Compare(end) > 0 {
+		return IPRange{}, ErrInvalidIPRangeOrder
+	}
+	return IPRange{Start: start, End: end}, nil
+}
+
+// ParseIPRange parses s in "start-end" form, e.g.
+// "172.18.40.10-172.18.40.20" or "abcd:1234::1-abcd:1234::ff".
+func ParseIPRange(s string) (IPRange, error) {
+	startStr, endStr, ok := strings.Cut(s, "-")
+	if !ok {
+		return IPRange{}, ErrInvalidIPRangeFormat
+	}
+
+	start, err := netip.ParseAddr(strings.TrimSpace(startStr))
+	if err != nil {
+		return IPRange{}, ErrInvalidIPRangeFormat
+	}
+	end, err := netip.ParseAddr(strings.TrimSpace(endStr))
+	if err != nil {
+		return IPRange{}, ErrInvalidIPRangeFormat
+	}
+
+	return NewIPRange(start, end)
+}
+
+// RangeFromCIDR returns the IPRange spanning every address of prefix,
+// including its network and broadcast addresses.
+func RangeFromCIDR(prefix netip.Prefix) (IPRange, error) {
+	if !prefix.IsValid() {
+		return IPRange{}, ErrInvalidCIDRFormat
+	}
+	masked := prefix.Masked()
+	return NewIPRange(masked.Addr(), lastAddrOf(masked))
+}
+
+// lastAddrOf returns the last (highest) address covered by prefix.
+func lastAddrOf(prefix netip.Prefix) netip.Addr {
+	addr := prefix.Addr()
+	hostBits := addr.BitLen() - prefix.Bits()
+
+	b := addr.As16()
+	for i := 0; i < hostBits; i++ {
+		byteIdx := 15 - i/8
+		b[byteIdx] |= 1 << uint(i%8)
+	}
+
+	last := netip.AddrFrom16(b)
+	if addr.Is4() {
+		last = last.Unmap()
+	}
+	return last
+}
+
+// Contains reports whether ip falls within the range.
+func (r IPRange) Contains(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	return ip.Compare(r.Start) >= 0 && ip.Compare(r.End) <= 0
+}
+
+// Overlaps reports whether r and o share at least one address.
+func (r IPRange) Overlaps(o IPRange) bool {
+	return r.Start.Compare(o.End) <= 0 && o.Start.Compare(r.End) <= 0
+}
+
+func (r IPRange) String() string {
+	return fmt.Sprintf("%s-%s", r.Start, r.End)
+}
@@ -0,0 +1,69 @@
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package ip_test
+
+import (
+	"net/netip"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	spiderpoolip "github.com/spidernet-io/spiderpool/pkg/ip"
+)
+
+var _ = Describe("IPRange", Label("range_test"), func() {
+	Describe("Test ParseIPRange", func() {
+		It("parses a valid IPv4 range", func() {
+			r, err := spiderpoolip.ParseIPRange("172.18.40.10-172.18.40.20")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(r.Start).To(Equal(netip.MustParseAddr("172.18.40.10")))
+			Expect(r.End).To(Equal(netip.MustParseAddr("172.18.40.20")))
+		})
+
+		It("rejects a malformed range", func() {
+			_, err := spiderpoolip.ParseIPRange("172.18.40.10")
+			Expect(err).To(MatchError(spiderpoolip.ErrInvalidIPRangeFormat))
+		})
+
+		It("rejects a range whose end precedes its start", func() {
+			_, err := spiderpoolip.ParseIPRange("172.18.40.20-172.18.40.10")
+			Expect(err).To(MatchError(spiderpoolip.ErrInvalidIPRangeOrder))
+		})
+
+		It("rejects mismatched families", func() {
+			_, err := spiderpoolip.ParseIPRange("172.18.40.10-abcd:1234::1")
+			Expect(err).To(MatchError(spiderpoolip.ErrMismatchedIPFamily))
+		})
+	})
+
+	Describe("Test RangeFromCIDR", func() {
+		It("spans the network and broadcast addresses of an IPv4 CIDR", func() {
+			r, err := spiderpoolip.RangeFromCIDR(netip.MustParsePrefix("172.18.40.0/24"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(r.Start).To(Equal(netip.MustParseAddr("172.18.40.0")))
+			Expect(r.End).To(Equal(netip.MustParseAddr("172.18.40.255")))
+		})
+
+		It("spans an IPv6 CIDR", func() {
+			r, err := spiderpoolip.RangeFromCIDR(netip.MustParsePrefix("abcd:1234::/120"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(r.Start).To(Equal(netip.MustParseAddr("abcd:1234::")))
+			Expect(r.End).To(Equal(netip.MustParseAddr("abcd:1234::ff")))
+		})
+	})
+
+	Describe("Test Overlaps", func() {
+		It("detects overlapping ranges", func() {
+			a, _ := spiderpoolip.ParseIPRange("172.18.40.0-172.18.40.20")
+			b, _ := spiderpoolip.ParseIPRange("172.18.40.10-172.18.40.30")
+			Expect(a.Overlaps(b)).To(BeTrue())
+		})
+
+		It("reports disjoint ranges as non-overlapping", func() {
+			a, _ := spiderpoolip.ParseIPRange("172.18.40.0-172.18.40.10")
+			b, _ := spiderpoolip.ParseIPRange("172.18.40.11-172.18.40.20")
+			Expect(a.Overlaps(b)).To(BeFalse())
+		})
+	})
+})
@@ -0,0 +1,324 @@
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package ip
+
+import (
+	"iter"
+	"math/big"
+	"net"
+	"net/netip"
+	"sort"
+)
+
+// RangeSet is a sorted, coalesced collection of disjoint IPRanges of a
+// single IP family. It subsumes the element-wise IPsDiffSet/IPsUnionSet/
+// IPsIntersectionSet helpers, which do an O(n*m) scan over every individual
+// net.IP: RangeSet's Union/Intersect run in O((n+m) log(n+m)) and never
+// materialize an address that isn't asked for, which matters once subnets
+// get wide enough that doing so would OOM (a /64, or even a /106, already
+// has more addresses than fit in memory).
+type RangeSet struct {
+	ranges []IPRange
+}
+
+// NewRangeSet returns an empty RangeSet.
+func NewRangeSet() *RangeSet {
+	return &RangeSet{}
+}
+
+// Ranges returns the set's disjoint, sorted ranges. The returned slice is a
+// copy and is safe for the caller to mutate.
+func (s *RangeSet) Ranges() []IPRange {
+	return append([]IPRange(nil), s.ranges...)
+}
+
+// Add inserts r into the set, coalescing it with any range it overlaps or
+// sits immediately adjacent to.
+func (s *RangeSet) Add(r IPRange) {
+	s.ranges = coalesce(append(s.ranges, r))
+}
+
+// Remove deletes every address in r from the set.
+func (s *RangeSet) Remove(r IPRange) {
+	out := make([]IPRange, 0, len(s.ranges))
+	for _, existing := range s.ranges {
+		out = append(out, subtractRange(existing, r)...)
+	}
+	s.ranges = out
+}
+
+// Contains reports whether ip falls within any of the set's ranges.
+func (s *RangeSet) Contains(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	i := sort.Search(len(s.ranges), func(i int) bool {
+		return s.ranges[i].End.Compare(ip) >= 0
+	})
+	return i < len(s.ranges) && s.ranges[i].Start.Compare(ip) <= 0
+}
+
+// Overlaps reports whether r intersects any of the set's ranges.
+func (s *RangeSet) Overlaps(r IPRange) bool {
+	for _, existing := range s.ranges {
+		if existing.Start.Compare(r.End) > 0 {
+			break
+		}
+		if existing.Overlaps(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// Union returns the set of addresses present in s or other.
+func (s *RangeSet) Union(other *RangeSet) *RangeSet {
+	merged := make([]IPRange, 0, len(s.ranges)+len(other.ranges))
+	merged = append(merged, s.ranges...)
+	merged = append(merged, other.ranges...)
+	return &RangeSet{ranges: coalesce(merged)}
+}
+
+// Intersect returns the set of addresses present in both s and other.
+func (s *RangeSet) Intersect(other *RangeSet) *RangeSet {
+	var out []IPRange
+	i, j := 0, 0
+	for i < len(s.ranges) && j < len(other.ranges) {
+		a, b := s.ranges[i], other.ranges[j]
+
+		start := maxAddr(a.Start, b.Start)
+		end := minAddr(a.End, b.End)
+		if start.Compare(end) <= 0 {
+			out = append(out, IPRange{Start: start, End: end})
+		}
+
+		if a.End.Compare(b.End) < 0 {
+			i++
+		} else {
+			j++
+		}
+	}
+	return &RangeSet{ranges: out}
+}
+
+// Difference returns the set of addresses present in s but not in other.
+func (s *RangeSet) Difference(other *RangeSet) *RangeSet {
+	out := make([]IPRange, 0, len(s.ranges))
+	for _, a := range s.ranges {
+		remaining := []IPRange{a}
+		for _, b := range other.ranges {
+			if b.End.Compare(a.Start) < 0 || b.Start.Compare(a.End) > 0 {
+				continue
+			}
+			next := make([]IPRange, 0, len(remaining))
+			for _, r := range remaining {
+				next = append(next, subtractRange(r, b)...)
+			}
+			remaining = next
+		}
+		out = append(out, remaining...)
+	}
+	return &RangeSet{ranges: coalesce(out)}
+}
+
+// Count returns the total number of addresses in the set. It is backed by
+// big.Int so that wide IPv6 ranges don't overflow a machine word.
+func (s *RangeSet) Count() *big.Int {
+	total := big.NewInt(0)
+	for _, r := range s.ranges {
+		count := new(big.Int).Sub(addrToBigInt(r.End), addrToBigInt(r.Start))
+		count.Add(count, big.NewInt(1))
+		total.Add(total, count)
+	}
+	return total
+}
+
+// Iter returns a sequence over every address in the set, in ascending
+// order. Iteration stops early if the consumer's yield func returns false.
+func (s *RangeSet) Iter() iter.Seq[netip.Addr] {
+	return func(yield func(netip.Addr) bool) {
+		for _, r := range s.ranges {
+			for addr := r.Start; ; addr = addr.Next() {
+				if !yield(addr) {
+					return
+				}
+				if addr == r.End {
+					break
+				}
+			}
+		}
+	}
+}
+
+// ToCIDRs returns the minimal list of CIDR prefixes covering exactly the
+// addresses in the set.
+func (s *RangeSet) ToCIDRs() []netip.Prefix {
+	var out []netip.Prefix
+	for _, r := range s.ranges {
+		out = append(out, rangeToCIDRs(r.Start, r.End)...)
+	}
+	return out
+}
+
+// FromCIDRs builds a RangeSet from a list of CIDR prefixes.
+func FromCIDRs(prefixes []netip.Prefix) (*RangeSet, error) {
+	s := NewRangeSet()
+	for _, prefix := range prefixes {
+		r, err := RangeFromCIDR(prefix)
+		if err != nil {
+			return nil, err
+		}
+		s.Add(r)
+	}
+	return s, nil
+}
+
+// RangeSetFromIPs builds a RangeSet out of a loose slice of addresses, for
+// callers still working with the element-wise []net.IP representation.
+func RangeSetFromIPs(ips []net.IP) *RangeSet {
+	s := NewRangeSet()
+	for _, ip := range ips {
+		addr, ok := addrFromNetIP(ip)
+		if !ok {
+			continue
+		}
+		s.Add(IPRange{Start: addr, End: addr})
+	}
+	return s
+}
+
+// ToIPs materializes every address in the set as a []net.IP, for
+// compatibility with callers of the older element-wise API. Prefer Iter or
+// Count for anything wider than a small pool.
+func (s *RangeSet) ToIPs() []net.IP {
+	var ips []net.IP
+	for addr := range s.Iter() {
+		ips = append(ips, netIPFromAddr(addr))
+	}
+	return ips
+}
+
+// coalesce sorts ranges by start address and merges every pair that
+// overlaps or sits immediately adjacent to one another.
+func coalesce(ranges []IPRange) []IPRange {
+	if len(ranges) == 0 {
+		return ranges
+	}
+
+	sort.Slice(ranges, func(i, j int) bool {
+		return ranges[i].Start.Compare(ranges[j].Start) < 0
+	})
+
+	out := make([]IPRange, 0, len(ranges))
+	cur := ranges[0]
+	for _, r := range ranges[1:] {
+		if canMerge(cur, r) {
+			if r.End.Compare(cur.End) > 0 {
+				cur.End = r.End
+			}
+			continue
+		}
+		out = append(out, cur)
+		cur = r
+	}
+	return append(out, cur)
+}
+
+// canMerge reports whether r overlaps cur or starts exactly where cur ends
+// plus one, assuming cur.Start <= r.Start.
+func canMerge(cur, r IPRange) bool {
+	if r.Start.Compare(cur.End) <= 0 {
+		return true
+	}
+	next := cur.End.Next()
+	return !next.IsValid() || r.Start.Compare(next) == 0
+}
+
+// subtractRange removes remove from existing, returning the 0, 1 or 2
+// ranges of existing left over.
+func subtractRange(existing, remove IPRange) []IPRange {
+	if !existing.Overlaps(remove) {
+		return []IPRange{existing}
+	}
+
+	var out []IPRange
+	if existing.Start.Compare(remove.Start) < 0 {
+		prevEnd := remove.Start.Prev()
+		if prevEnd.IsValid() && prevEnd.Compare(existing.Start) >= 0 {
+			out = append(out, IPRange{Start: existing.Start, End: prevEnd})
+		}
+	}
+	if existing.End.Compare(remove.End) > 0 {
+		nextStart := remove.End.Next()
+		if nextStart.IsValid() && nextStart.Compare(existing.End) <= 0 {
+			out = append(out, IPRange{Start: nextStart, End: existing.End})
+		}
+	}
+	return out
+}
+
+func maxAddr(a, b netip.Addr) netip.Addr {
+	if a.Compare(b) >= 0 {
+		return a
+	}
+	return b
+}
+
+func minAddr(a, b netip.Addr) netip.Addr {
+	if a.Compare(b) <= 0 {
+		return a
+	}
+	return b
+}
+
+func addrToBigInt(addr netip.Addr) *big.Int {
+	addr = addr.Unmap()
+	if addr.Is4() {
+		b := addr.As4()
+		return new(big.Int).SetBytes(b[:])
+	}
+	b := addr.As16()
+	return new(big.Int).SetBytes(b[:])
+}
+
+// rangeToCIDRs computes the minimal list of CIDR prefixes covering exactly
+// [start, end], by repeatedly taking the largest block aligned at the
+// current address that doesn't run past end.
+func rangeToCIDRs(start, end netip.Addr) []netip.Prefix {
+	bits := start.BitLen()
+
+	var out []netip.Prefix
+	cur := start
+	for {
+		maxHostBits := trailingZeroBits(cur, bits)
+		for maxHostBits > 0 {
+			candidate := netip.PrefixFrom(cur, bits-maxHostBits)
+			if lastAddrOf(candidate).Compare(end) <= 0 {
+				break
+			}
+			maxHostBits--
+		}
+
+		prefix := netip.PrefixFrom(cur, bits-maxHostBits)
+		out = append(out, prefix)
+
+		last := lastAddrOf(prefix)
+		if last == end {
+			break
+		}
+		cur = last.Next()
+	}
+	return out
+}
+
+// trailingZeroBits returns the number of trailing zero bits in addr's
+// integer form, capped to bits.
+func trailingZeroBits(addr netip.Addr, bits int) int {
+	n := addrToBigInt(addr)
+	if n.Sign() == 0 {
+		return bits
+	}
+	if tz := int(n.TrailingZeroBits()); tz < bits {
+		return tz
+	}
+	return bits
+}
@@ -0,0 +1,110 @@
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package ip_test
+
+import (
+	"net/netip"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	spiderpoolip "github.com/spidernet-io/spiderpool/pkg/ip"
+)
+
+func mustRange(s string) spiderpoolip.IPRange {
+	r, err := spiderpoolip.ParseIPRange(s)
+	Expect(err).NotTo(HaveOccurred())
+	return r
+}
+
+var _ = Describe("RangeSet", Label("rangeset_test"), func() {
+	Describe("Test Add", func() {
+		It("coalesces overlapping and adjacent ranges", func() {
+			s := spiderpoolip.NewRangeSet()
+			s.Add(mustRange("172.18.40.0-172.18.40.10"))
+			s.Add(mustRange("172.18.40.11-172.18.40.20"))
+			s.Add(mustRange("172.18.40.5-172.18.40.8"))
+
+			Expect(s.Ranges()).To(Equal([]spiderpoolip.IPRange{
+				mustRange("172.18.40.0-172.18.40.20"),
+			}))
+		})
+	})
+
+	Describe("Test Remove", func() {
+		It("splits a range when removing from its middle", func() {
+			s := spiderpoolip.NewRangeSet()
+			s.Add(mustRange("172.18.40.0-172.18.40.20"))
+			s.Remove(mustRange("172.18.40.10-172.18.40.12"))
+
+			Expect(s.Ranges()).To(Equal([]spiderpoolip.IPRange{
+				mustRange("172.18.40.0-172.18.40.9"),
+				mustRange("172.18.40.13-172.18.40.20"),
+			}))
+		})
+	})
+
+	Describe("Test Contains", func() {
+		It("reports membership of an address", func() {
+			s := spiderpoolip.NewRangeSet()
+			s.Add(mustRange("172.18.40.0-172.18.40.10"))
+
+			Expect(s.Contains(netip.MustParseAddr("172.18.40.5"))).To(BeTrue())
+			Expect(s.Contains(netip.MustParseAddr("172.18.40.11"))).To(BeFalse())
+		})
+	})
+
+	Describe("Test Union/Intersect/Difference", func() {
+		It("computes set algebra over two RangeSets", func() {
+			a := spiderpoolip.NewRangeSet()
+			a.Add(mustRange("172.18.40.0-172.18.40.20"))
+
+			b := spiderpoolip.NewRangeSet()
+			b.Add(mustRange("172.18.40.10-172.18.40.30"))
+
+			Expect(a.Union(b).Ranges()).To(Equal([]spiderpoolip.IPRange{
+				mustRange("172.18.40.0-172.18.40.30"),
+			}))
+			Expect(a.Intersect(b).Ranges()).To(Equal([]spiderpoolip.IPRange{
+				mustRange("172.18.40.10-172.18.40.20"),
+			}))
+			Expect(a.Difference(b).Ranges()).To(Equal([]spiderpoolip.IPRange{
+				mustRange("172.18.40.0-172.18.40.9"),
+			}))
+		})
+	})
+
+	Describe("Test Count", func() {
+		It("counts addresses across ranges with big.Int", func() {
+			s := spiderpoolip.NewRangeSet()
+			s.Add(mustRange("172.18.40.0-172.18.40.9"))
+			s.Add(mustRange("172.18.41.0-172.18.41.9"))
+			Expect(s.Count().Int64()).To(Equal(int64(20)))
+		})
+	})
+
+	Describe("Test ToCIDRs/FromCIDRs", func() {
+		It("round-trips a range through its minimal covering prefixes", func() {
+			s := spiderpoolip.NewRangeSet()
+			s.Add(mustRange("172.18.40.0-172.18.40.255"))
+
+			cidrs := s.ToCIDRs()
+			Expect(cidrs).To(Equal([]netip.Prefix{netip.MustParsePrefix("172.18.40.0/24")}))
+
+			rebuilt, err := spiderpoolip.FromCIDRs(cidrs)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rebuilt.Ranges()).To(Equal(s.Ranges()))
+		})
+
+		It("splits a non-aligned range into multiple prefixes", func() {
+			s := spiderpoolip.NewRangeSet()
+			s.Add(mustRange("172.18.40.0-172.18.40.2"))
+
+			Expect(s.ToCIDRs()).To(Equal([]netip.Prefix{
+				netip.MustParsePrefix("172.18.40.0/31"),
+				netip.MustParsePrefix("172.18.40.2/32"),
+			}))
+		})
+	})
+})
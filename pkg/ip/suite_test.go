@@ -0,0 +1,22 @@
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package ip_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+const (
+	invalidIPVersion int64 = 5
+	invalidIP              = "invalid_ip"
+	invalidCIDR            = "invalid_cidr"
+)
+
+func TestIP(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "IP Suite")
+}
@@ -0,0 +1,53 @@
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package ip
+
+import (
+	"net"
+	"net/netip"
+
+	"github.com/spidernet-io/spiderpool/pkg/constant"
+)
+
+// addrFromNetIP converts a net.IP (either the 4-byte or the 16-byte,
+// v4-in-v6 form) into a netip.Addr with the v4-in-v6 wrapping stripped off,
+// so that family checks and comparisons are representation-agnostic.
+func addrFromNetIP(ip net.IP) (netip.Addr, bool) {
+	addr, ok := netip.AddrFromSlice(ip)
+	if !ok {
+		return netip.Addr{}, false
+	}
+	return addr.Unmap(), true
+}
+
+// addrMatchesVersion reports whether addr belongs to the given IP version.
+func addrMatchesVersion(addr netip.Addr, version int64) bool {
+	switch version {
+	case constant.IPv4:
+		return addr.Is4()
+	case constant.IPv6:
+		return addr.Is6()
+	default:
+		return false
+	}
+}
+
+// addrBits returns the bit length of an address of the given family.
+func addrBits(version int64) int {
+	if version == constant.IPv4 {
+		return 32
+	}
+	return 128
+}
+
+// netIPFromAddr renders addr back into the "long form" net.IP that the
+// standard library itself produces for the family (16 bytes, v4-in-v6
+// mapped for IPv4), matching what net.ParseIP/net.IPv4 return.
+func netIPFromAddr(addr netip.Addr) net.IP {
+	if addr.Is4() {
+		b := addr.As4()
+		return net.IPv4(b[0], b[1], b[2], b[3])
+	}
+	return net.IP(addr.AsSlice())
+}
@@ -3,16 +3,63 @@
 
 package limiter
 
-import "time"
+import (
+	"context"
+	"time"
+)
+
+// Strategy selects which Limiter implementation LimiterConfig builds.
+type Strategy string
+
+const (
+	// StrategyFIFO admits waiters strictly in arrival order, the
+	// behavior LimiterConfig always had before Strategy existed.
+	StrategyFIFO Strategy = "FIFO"
+	// StrategyWFQ admits waiters in round-robin order across keys, so a
+	// single key with many waiters cannot starve the others out.
+	StrategyWFQ Strategy = "WFQ"
+	// StrategyTokenBucket enforces an independent per-key rate limit.
+	StrategyTokenBucket Strategy = "TokenBucket"
+)
 
 const (
 	defaultMaxQueueSize = 1000
 	defaultMaxWaitTime  = 15 * time.Second
+	defaultRate         = 50
+	defaultBurst        = 100
+	defaultRefill       = time.Second
 )
 
+// LimiterConfig configures the Limiter returned by New. MaxWaitTime applies
+// to every strategy; MaxQueueSize only to StrategyFIFO and StrategyWFQ.
+// Rate and Refill are only consulted by StrategyTokenBucket. Burst is the
+// bucket size for StrategyTokenBucket and the admission capacity for
+// StrategyFIFO and StrategyWFQ. KeyFunc is consulted by StrategyTokenBucket
+// and StrategyWFQ.
 type LimiterConfig struct {
 	MaxQueueSize *int
 	MaxWaitTime  *time.Duration
+
+	// Strategy selects the admission policy. Defaults to StrategyFIFO.
+	Strategy Strategy
+
+	// Rate is the number of tokens added to a key's bucket every Refill
+	// interval, for StrategyTokenBucket.
+	Rate float64
+	// Burst is the maximum number of tokens a key's bucket can hold, for
+	// StrategyTokenBucket. For StrategyFIFO and StrategyWFQ it is the total
+	// weight admitted at once.
+	Burst int
+	// Refill is the interval at which Rate tokens are added back to a
+	// key's bucket, for StrategyTokenBucket.
+	Refill time.Duration
+
+	// KeyFunc derives the admission key (e.g. a Node name or a
+	// SpiderIPPool name) from the request context when Acquire is called
+	// with an empty key. It defaults to a constant key, which makes such
+	// calls share a single rate limit under StrategyTokenBucket and a
+	// single queue under StrategyWFQ. StrategyFIFO ignores it.
+	KeyFunc func(ctx context.Context) string
 }
 
 func setDefaultsForLimiterConfig(config LimiterConfig) LimiterConfig {
@@ -25,5 +72,25 @@ func setDefaultsForLimiterConfig(config LimiterConfig) LimiterConfig {
 		config.MaxWaitTime = &maxWaitTime
 	}
 
+	if config.Strategy == "" {
+		config.Strategy = StrategyFIFO
+	}
+
+	if config.Rate <= 0 {
+		config.Rate = defaultRate
+	}
+
+	if config.Burst <= 0 {
+		config.Burst = defaultBurst
+	}
+
+	if config.Refill <= 0 {
+		config.Refill = defaultRefill
+	}
+
+	if config.KeyFunc == nil {
+		config.KeyFunc = func(context.Context) string { return "default" }
+	}
+
 	return config
 }
@@ -0,0 +1,68 @@
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+// Package limiter bounds concurrent access to a contended resource - the
+// IPAM allocate/release path chief among them - behind a small Limiter
+// interface so the admission policy (FIFO, per-key token bucket, or
+// weighted-fair-queue) can be swapped via LimiterConfig.Strategy without
+// touching callers.
+package limiter
+
+import (
+	"context"
+	"errors"
+)
+
+var (
+	// ErrQueueFull is returned by Acquire when the waiter queue has
+	// already reached LimiterConfig.MaxQueueSize.
+	ErrQueueFull = errors.New("limiter: queue is full")
+	// ErrWaitTimeout is returned by Acquire when a caller waited longer
+	// than LimiterConfig.MaxWaitTime without being admitted.
+	ErrWaitTimeout = errors.New("limiter: timed out waiting to be admitted")
+)
+
+// Ticket is the admission handle returned by Acquire. It must be passed to
+// Release exactly once to give its weight back to the limiter.
+type Ticket struct {
+	key    string
+	weight int
+}
+
+// Limiter admits callers into a bounded resource, optionally partitioned by
+// key (e.g. one bucket per Node, or per SpiderIPPool) so that a single hot
+// key cannot starve admission for every other key.
+type Limiter interface {
+	// Acquire blocks until weight units of admission are available for
+	// key, ctx is done, or the wait exceeds LimiterConfig.MaxWaitTime.
+	Acquire(ctx context.Context, key string, weight int) (Ticket, error)
+	// Release gives ticket's weight back to the limiter.
+	Release(ticket Ticket)
+}
+
+// New builds a Limiter for the given config. The zero LimiterConfig builds
+// a FIFO limiter with the package's defaults.
+func New(config LimiterConfig) Limiter {
+	config = setDefaultsForLimiterConfig(config)
+	m := newMetrics(string(config.Strategy))
+
+	switch config.Strategy {
+	case StrategyTokenBucket:
+		return newTokenBucketLimiter(config, m)
+	case StrategyWFQ:
+		return newWFQLimiter(config, m)
+	default:
+		// FIFO is WFQ with every waiter sharing one key: round-robin
+		// across a single key degenerates to pure arrival order.
+		config.KeyFunc = func(context.Context) string { return "fifo" }
+		return fifoLimiter{newWFQLimiter(config, m)}
+	}
+}
+
+// fifoLimiter queues every caller under the single "fifo" key, ignoring the
+// key passed to Acquire.
+type fifoLimiter struct{ *wfqLimiter }
+
+func (l fifoLimiter) Acquire(ctx context.Context, _ string, weight int) (Ticket, error) {
+	return l.wfqLimiter.Acquire(ctx, "", weight)
+}
@@ -0,0 +1,123 @@
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package limiter
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func durationPtr(d time.Duration) *time.Duration { return &d }
+func intPtr(i int) *int                           { return &i }
+
+func TestTokenBucketLimiterPerKeyIsolation(t *testing.T) {
+	l := New(LimiterConfig{
+		Strategy:    StrategyTokenBucket,
+		Rate:        1,
+		Burst:       1,
+		Refill:      time.Hour,
+		MaxWaitTime: durationPtr(10 * time.Millisecond),
+	})
+
+	ctx := context.Background()
+	if _, err := l.Acquire(ctx, "hot", 1); err != nil {
+		t.Fatalf("expected first acquire for key %q to succeed, got %v", "hot", err)
+	}
+
+	if _, err := l.Acquire(ctx, "hot", 1); err != ErrWaitTimeout {
+		t.Fatalf("expected a drained bucket to time out, got %v", err)
+	}
+
+	if _, err := l.Acquire(ctx, "cold", 1); err != nil {
+		t.Fatalf("expected a different key to have its own bucket, got %v", err)
+	}
+}
+
+// TestWFQLimiterRoundRobinFavorsLeastRecentlyServedKey enqueues waiters
+// directly (rather than racing goroutines against each other through
+// Acquire) so the arrival order is deterministic: key "a" builds up a
+// two-deep backlog before key "b" ever queues. If admission just drained
+// each key's queue in arrival order, "a" would win twice in a row; WFQ's
+// round-robin must instead serve "b" in between.
+func TestWFQLimiterRoundRobinFavorsLeastRecentlyServedKey(t *testing.T) {
+	l := New(LimiterConfig{
+		Strategy:     StrategyWFQ,
+		Burst:        1,
+		MaxQueueSize: intPtr(10),
+		MaxWaitTime:  durationPtr(time.Second),
+	}).(*wfqLimiter)
+
+	ctx := context.Background()
+	first, err := l.Acquire(ctx, "a", 1)
+	if err != nil {
+		t.Fatalf("expected the first caller to be admitted immediately, got %v", err)
+	}
+
+	a1, admitted := l.enqueue("a", 1)
+	if admitted {
+		t.Fatal("expected the second caller for key \"a\" to queue, not be admitted immediately")
+	}
+	a2, admitted := l.enqueue("a", 1)
+	if admitted {
+		t.Fatal("expected the third caller for key \"a\" to queue, not be admitted immediately")
+	}
+	b1, admitted := l.enqueue("b", 1)
+	if admitted {
+		t.Fatal("expected the caller for key \"b\" to queue, not be admitted immediately")
+	}
+
+	l.Release(first)
+	mustBeGranted(t, a1, "a's first queued waiter")
+
+	l.Release(Ticket{key: "a", weight: 1})
+	mustBeGranted(t, b1, "b's waiter (round-robin should serve key \"b\" before draining key \"a\"'s backlog)")
+
+	l.Release(Ticket{key: "b", weight: 1})
+	mustBeGranted(t, a2, "a's second queued waiter")
+}
+
+func mustBeGranted(t *testing.T, w *waiter, name string) {
+	t.Helper()
+	select {
+	case <-w.granted:
+	case <-time.After(time.Second):
+		t.Fatalf("expected %s to be granted", name)
+	}
+}
+
+func TestFIFOLimiterIsOrdered(t *testing.T) {
+	l := New(LimiterConfig{
+		Strategy:     StrategyFIFO,
+		Burst:        1,
+		MaxQueueSize: intPtr(10),
+		MaxWaitTime:  durationPtr(time.Second),
+	})
+
+	ctx := context.Background()
+	first, err := l.Acquire(ctx, "ignored-by-fifo", 1)
+	if err != nil {
+		t.Fatalf("expected the first caller to be admitted immediately, got %v", err)
+	}
+
+	admitted := make(chan int, 1)
+	go func() {
+		if _, err := l.Acquire(ctx, "ignored-by-fifo", 1); err == nil {
+			admitted <- 1
+		}
+	}()
+
+	select {
+	case <-admitted:
+		t.Fatal("second caller should not be admitted before capacity is released")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	l.Release(first)
+	select {
+	case <-admitted:
+	case <-time.After(time.Second):
+		t.Fatal("second caller was never admitted after capacity was released")
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package limiter
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// metrics are the Prometheus series exposed by every Limiter implementation
+// so operators can diagnose contention during Pod-churn storms: how deep
+// the queue got, how long admitted callers waited, how many callers were
+// dropped, and the per-key admission rate.
+type metrics struct {
+	queueDepth    *prometheus.GaugeVec
+	waitDuration  *prometheus.HistogramVec
+	droppedTotal  *prometheus.CounterVec
+	admittedTotal *prometheus.CounterVec
+}
+
+// metricsByStrategy caches the metrics registered for each strategy so that
+// New can be called more than once per strategy without re-registering the
+// same collector with Prometheus's default registry.
+var (
+	metricsMu      sync.Mutex
+	metricsByStrategy = map[string]*metrics{}
+)
+
+func newMetrics(strategy string) *metrics {
+	metricsMu.Lock()
+	defer metricsMu.Unlock()
+
+	if m, ok := metricsByStrategy[strategy]; ok {
+		return m
+	}
+
+	m := buildMetrics(strategy)
+	metricsByStrategy[strategy] = m
+	return m
+}
+
+func buildMetrics(strategy string) *metrics {
+	labels := prometheus.Labels{"strategy": strategy}
+
+	return &metrics{
+		queueDepth: promauto.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace:   "spiderpool",
+			Subsystem:   "limiter",
+			Name:        "queue_depth",
+			Help:        "Number of callers currently waiting to be admitted, by key.",
+			ConstLabels: labels,
+		}, []string{"key"}),
+		waitDuration: promauto.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace:   "spiderpool",
+			Subsystem:   "limiter",
+			Name:        "wait_duration_seconds",
+			Help:        "Time callers spent waiting to be admitted, by key.",
+			ConstLabels: labels,
+			Buckets:     prometheus.DefBuckets,
+		}, []string{"key"}),
+		droppedTotal: promauto.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   "spiderpool",
+			Subsystem:   "limiter",
+			Name:        "dropped_total",
+			Help:        "Number of callers rejected with ErrQueueFull or ErrWaitTimeout, by key.",
+			ConstLabels: labels,
+		}, []string{"key"}),
+		admittedTotal: promauto.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   "spiderpool",
+			Subsystem:   "limiter",
+			Name:        "admitted_total",
+			Help:        "Number of callers admitted, by key.",
+			ConstLabels: labels,
+		}, []string{"key"}),
+	}
+}
@@ -0,0 +1,116 @@
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package limiter
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// tokenBucketLimiter enforces an independent rate limit per key: each key
+// gets its own bucket of up to Burst tokens, refilled at Rate tokens every
+// Refill interval. A caller is admitted once its key's bucket holds at
+// least weight tokens, so a single hot key (a Node issuing a burst of IPAM
+// allocate calls) can only exhaust its own bucket, never another key's.
+type tokenBucketLimiter struct {
+	rate        float64
+	burst       int
+	refill      time.Duration
+	maxWaitTime time.Duration
+	keyFunc     func(context.Context) string
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+
+	metrics *metrics
+}
+
+type bucket struct {
+	tokens     float64
+	lastRefill time.Time
+}
+
+func newTokenBucketLimiter(config LimiterConfig, m *metrics) *tokenBucketLimiter {
+	return &tokenBucketLimiter{
+		rate:        config.Rate,
+		burst:       config.Burst,
+		refill:      config.Refill,
+		maxWaitTime: *config.MaxWaitTime,
+		keyFunc:     config.KeyFunc,
+		buckets:     map[string]*bucket{},
+		metrics:     m,
+	}
+}
+
+func (l *tokenBucketLimiter) Acquire(ctx context.Context, key string, weight int) (Ticket, error) {
+	if key == "" {
+		key = l.keyFunc(ctx)
+	}
+
+	start := time.Now()
+	for {
+		admitted, retryAfter := l.tryAcquire(key, weight)
+		if admitted {
+			l.metrics.admittedTotal.WithLabelValues(key).Inc()
+			l.metrics.waitDuration.WithLabelValues(key).Observe(time.Since(start).Seconds())
+			return Ticket{key: key, weight: weight}, nil
+		}
+
+		if time.Since(start)+retryAfter > l.maxWaitTime {
+			l.metrics.droppedTotal.WithLabelValues(key).Inc()
+			return Ticket{}, ErrWaitTimeout
+		}
+
+		timer := time.NewTimer(retryAfter)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return Ticket{}, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// Release is a no-op: token bucket capacity is restored by the passage of
+// time, not by the caller giving its weight back.
+func (l *tokenBucketLimiter) Release(Ticket) {}
+
+// tryAcquire reports whether key's bucket currently holds enough tokens for
+// weight. If not, it returns the minimum duration the caller must wait
+// before enough tokens will have accrued.
+func (l *tokenBucketLimiter) tryAcquire(key string, weight int) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(l.burst), lastRefill: time.Now()}
+		l.buckets[key] = b
+	}
+	l.refillLocked(b)
+
+	if b.tokens >= float64(weight) {
+		b.tokens -= float64(weight)
+		return true, 0
+	}
+
+	deficit := float64(weight) - b.tokens
+	intervalsNeeded := deficit / l.rate
+	return false, time.Duration(float64(l.refill) * intervalsNeeded)
+}
+
+func (l *tokenBucketLimiter) refillLocked(b *bucket) {
+	elapsed := time.Since(b.lastRefill)
+	if elapsed <= 0 {
+		return
+	}
+
+	intervals := elapsed.Seconds() / l.refill.Seconds()
+	b.tokens += intervals * l.rate
+	if b.tokens > float64(l.burst) {
+		b.tokens = float64(l.burst)
+	}
+	b.lastRefill = b.lastRefill.Add(elapsed)
+}
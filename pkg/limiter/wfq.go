@@ -0,0 +1,170 @@
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+package limiter
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// wfqLimiter admits up to `capacity` weight worth of callers concurrently.
+// When capacity is exhausted,
+// callers queue per key; whenever capacity frees up, the next waiter is
+// picked by rotating through keys round-robin rather than draining one
+// key's queue before looking at the next, so a key with many waiters
+// cannot starve the others out. With a single, constant key (the default)
+// this degenerates to plain FIFO order.
+type wfqLimiter struct {
+	capacity    int
+	maxQueued   int
+	maxWaitTime time.Duration
+	keyFunc     func(context.Context) string
+
+	mu       sync.Mutex
+	inUse    int
+	queues   map[string][]*waiter
+	rotation []string // keys with at least one waiter, in round-robin order
+	queued   int
+
+	metrics *metrics
+}
+
+type waiter struct {
+	weight  int
+	granted chan struct{}
+}
+
+func newWFQLimiter(config LimiterConfig, m *metrics) *wfqLimiter {
+	return &wfqLimiter{
+		capacity:    config.Burst,
+		maxQueued:   *config.MaxQueueSize,
+		maxWaitTime: *config.MaxWaitTime,
+		keyFunc:     config.KeyFunc,
+		queues:      map[string][]*waiter{},
+		metrics:     m,
+	}
+}
+
+func (l *wfqLimiter) Acquire(ctx context.Context, key string, weight int) (Ticket, error) {
+	if key == "" {
+		key = l.keyFunc(ctx)
+	}
+
+	start := time.Now()
+	w, admittedImmediately := l.enqueue(key, weight)
+	if admittedImmediately {
+		l.metrics.admittedTotal.WithLabelValues(key).Inc()
+		return Ticket{key: key, weight: weight}, nil
+	}
+	if w == nil {
+		l.metrics.droppedTotal.WithLabelValues(key).Inc()
+		return Ticket{}, ErrQueueFull
+	}
+
+	timer := time.NewTimer(time.Until(start.Add(l.maxWaitTime)))
+	defer timer.Stop()
+
+	select {
+	case <-w.granted:
+		l.metrics.admittedTotal.WithLabelValues(key).Inc()
+		l.metrics.waitDuration.WithLabelValues(key).Observe(time.Since(start).Seconds())
+		return Ticket{key: key, weight: weight}, nil
+	case <-timer.C:
+		l.abandon(key, w)
+		l.metrics.droppedTotal.WithLabelValues(key).Inc()
+		return Ticket{}, ErrWaitTimeout
+	case <-ctx.Done():
+		l.abandon(key, w)
+		return Ticket{}, ctx.Err()
+	}
+}
+
+func (l *wfqLimiter) Release(ticket Ticket) {
+	l.mu.Lock()
+	l.inUse -= ticket.weight
+	l.admitLocked()
+	l.mu.Unlock()
+}
+
+// enqueue either admits the caller immediately (when capacity is free and
+// nobody else is already waiting), or queues it and returns its waiter.
+// It returns (nil, false) if the queue was already full.
+func (l *wfqLimiter) enqueue(key string, weight int) (*waiter, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.queued == 0 && l.inUse+weight <= l.capacity {
+		l.inUse += weight
+		return nil, true
+	}
+
+	if l.queued >= l.maxQueued {
+		return nil, false
+	}
+
+	w := &waiter{weight: weight, granted: make(chan struct{})}
+	if _, ok := l.queues[key]; !ok {
+		l.rotation = append(l.rotation, key)
+	}
+	l.queues[key] = append(l.queues[key], w)
+	l.queued++
+	l.metrics.queueDepth.WithLabelValues(key).Set(float64(len(l.queues[key])))
+	return w, false
+}
+
+// admitLocked grants capacity to waiters, rotating across keys, until
+// either capacity or the waiter queue is exhausted. Callers must hold l.mu.
+func (l *wfqLimiter) admitLocked() {
+	for len(l.rotation) > 0 {
+		key := l.rotation[0]
+		q := l.queues[key]
+		if len(q) == 0 {
+			l.rotation = l.rotation[1:]
+			delete(l.queues, key)
+			continue
+		}
+
+		next := q[0]
+		if l.inUse+next.weight > l.capacity {
+			return
+		}
+
+		l.inUse += next.weight
+		l.queues[key] = q[1:]
+		l.queued--
+		l.metrics.queueDepth.WithLabelValues(key).Set(float64(len(l.queues[key])))
+		close(next.granted)
+
+		// Rotate key to the back so the next admission round favors a
+		// different key first.
+		l.rotation = append(l.rotation[1:], key)
+	}
+}
+
+// abandon removes w from key's queue if it is still waiting (it may have
+// just been granted concurrently, in which case this is a no-op).
+func (l *wfqLimiter) abandon(key string, w *waiter) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	select {
+	case <-w.granted:
+		// Already admitted; give its weight straight back.
+		l.inUse -= w.weight
+		l.admitLocked()
+		return
+	default:
+	}
+
+	q := l.queues[key]
+	for i, queued := range q {
+		if queued == w {
+			l.queues[key] = append(q[:i], q[i+1:]...)
+			l.queued--
+			l.metrics.queueDepth.WithLabelValues(key).Set(float64(len(l.queues[key])))
+			break
+		}
+	}
+}
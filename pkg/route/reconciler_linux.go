@@ -0,0 +1,149 @@
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build linux
+
+package route
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+
+	"github.com/vishvananda/netlink"
+)
+
+// linuxReconciler reconciles the routes of a single link via netlink.
+type linuxReconciler struct {
+	linkIndex int
+}
+
+// NewReconciler returns a Reconciler that installs and removes routes on
+// the named link.
+func NewReconciler(linkName string) (Reconciler, error) {
+	link, err := netlink.LinkByName(linkName)
+	if err != nil {
+		return nil, fmt.Errorf("route: failed to find link %q: %w", linkName, err)
+	}
+	return &linuxReconciler{linkIndex: link.Attrs().Index}, nil
+}
+
+func (r *linuxReconciler) Run(stopCh <-chan struct{}, updates <-chan Update) error {
+	for {
+		select {
+		case <-stopCh:
+			return nil
+		case u, ok := <-updates:
+			if !ok {
+				return nil
+			}
+			if err := r.Reconcile(u.Desired, u.Gateway); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (r *linuxReconciler) Reconcile(desired []Prefix, gw netip.Addr) error {
+	existing, err := r.listManagedRoutes(gw)
+	if err != nil {
+		return err
+	}
+
+	desiredSet := make(map[netip.Prefix]struct{}, len(desired))
+	for _, p := range desired {
+		desiredSet[p.Dst] = struct{}{}
+	}
+
+	// Install everything missing first. This guarantees that when a
+	// desired prefix shrinks (e.g. the ServiceCIDR goes from /16 to
+	// /20), the narrower route is already in place before its wider,
+	// now-stale covering route is removed below - there is never a gap
+	// where neither route exists.
+	for _, p := range desired {
+		if _, ok := existing[p.Dst]; ok {
+			continue
+		}
+		if err := r.addRoute(p, gw); err != nil {
+			return fmt.Errorf("route: failed to add route for %s via %s: %w", p.Dst, gw, err)
+		}
+	}
+
+	// Remove anything installed for this gateway that is no longer
+	// desired. The add loop above already ran to completion, so a
+	// shrinking or growing prefix's replacement is already in place
+	// before its now-stale counterpart is removed here - there is never
+	// a gap where neither route exists. Every other route that is simply
+	// no longer desired is just as stale, including one disjoint from
+	// every current prefix (e.g. a CIDR that moved or was dropped
+	// outright), so it is removed unconditionally rather than only when
+	// it happens to overlap a replacement.
+	for dst, rt := range existing {
+		if _, ok := desiredSet[dst]; ok {
+			continue
+		}
+		if err := netlink.RouteDel(&rt); err != nil {
+			return fmt.Errorf("route: failed to remove stale route for %s: %w", dst, err)
+		}
+	}
+
+	return nil
+}
+
+func (r *linuxReconciler) addRoute(p Prefix, gw netip.Addr) error {
+	return netlink.RouteReplace(&netlink.Route{
+		LinkIndex: r.linkIndex,
+		Dst:       prefixToIPNet(p.Dst),
+		Gw:        gw.AsSlice(),
+		Table:     p.Table,
+	})
+}
+
+// listManagedRoutes returns every route on this link, keyed by destination
+// prefix, whose gateway is gw.
+func (r *linuxReconciler) listManagedRoutes(gw netip.Addr) (map[netip.Prefix]netlink.Route, error) {
+	routes, err := netlink.RouteList(nil, netlink.FAMILY_ALL)
+	if err != nil {
+		return nil, fmt.Errorf("route: failed to list routes: %w", err)
+	}
+
+	managed := make(map[netip.Prefix]netlink.Route)
+	for _, rt := range routes {
+		if rt.LinkIndex != r.linkIndex || rt.Dst == nil || len(rt.Gw) == 0 {
+			continue
+		}
+
+		rtGw, ok := netip.AddrFromSlice(rt.Gw)
+		if !ok || rtGw.Unmap() != gw.Unmap() {
+			continue
+		}
+
+		prefix, err := ipNetToPrefix(rt.Dst)
+		if err != nil {
+			continue
+		}
+		managed[prefix] = rt
+	}
+	return managed, nil
+}
+
+func prefixToIPNet(p netip.Prefix) *net.IPNet {
+	addr := p.Addr()
+	bits := addr.BitLen()
+	if addr.Is4() {
+		b := addr.As4()
+		return &net.IPNet{IP: net.IP(b[:]), Mask: net.CIDRMask(p.Bits(), bits)}
+	}
+	b := addr.As16()
+	return &net.IPNet{IP: net.IP(b[:]), Mask: net.CIDRMask(p.Bits(), bits)}
+}
+
+func ipNetToPrefix(n *net.IPNet) (netip.Prefix, error) {
+	addr, ok := netip.AddrFromSlice(n.IP)
+	if !ok {
+		return netip.Prefix{}, fmt.Errorf("route: invalid destination address %s", n.IP)
+	}
+	addr = addr.Unmap()
+	ones, _ := n.Mask.Size()
+	return netip.PrefixFrom(addr, ones), nil
+}
@@ -0,0 +1,24 @@
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build linux
+
+package route
+
+import (
+	"net/netip"
+	"testing"
+)
+
+func TestPrefixIPNetRoundTrip(t *testing.T) {
+	for _, s := range []string{"10.96.0.0/16", "abcd:1234::/64"} {
+		prefix := netip.MustParsePrefix(s)
+		back, err := ipNetToPrefix(prefixToIPNet(prefix))
+		if err != nil {
+			t.Fatalf("round-tripping %s: %v", s, err)
+		}
+		if back != prefix {
+			t.Fatalf("round-tripping %s: got %s", s, back)
+		}
+	}
+}
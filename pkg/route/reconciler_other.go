@@ -0,0 +1,31 @@
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build !linux
+
+package route
+
+import (
+	"errors"
+	"net/netip"
+)
+
+// ErrUnsupportedPlatform is returned by NewReconciler on platforms other
+// than Linux; spiderpool-agent's BGP/underlay routing only targets Linux
+// nodes.
+var ErrUnsupportedPlatform = errors.New("route: reconciler is only implemented on linux")
+
+type unsupportedReconciler struct{}
+
+// NewReconciler returns an error on non-Linux platforms.
+func NewReconciler(linkName string) (Reconciler, error) {
+	return nil, ErrUnsupportedPlatform
+}
+
+func (unsupportedReconciler) Reconcile(desired []Prefix, gw netip.Addr) error {
+	return ErrUnsupportedPlatform
+}
+
+func (unsupportedReconciler) Run(stopCh <-chan struct{}, updates <-chan Update) error {
+	return ErrUnsupportedPlatform
+}
@@ -0,0 +1,46 @@
+// Copyright 2022 Authors of spidernet-io
+// SPDX-License-Identifier: Apache-2.0
+
+// Package route reconciles a desired set of destination prefixes against
+// the kernel routing table, so spiderpool-agent can own on-Node routing
+// for BGP/underlay deployments rather than relying on an external CNI to
+// install Service/Pod CIDR routes.
+//
+// Known gap: spiderpool-agent does not call NewReconciler or feed it
+// Updates yet - the Service/Pod CIDR discovery loop that would produce
+// those Updates lives outside this tree snapshot. This package's tests
+// cover only the netip.Prefix/net.IPNet conversion helpers; Reconcile and
+// Run are not exercised by them, and nothing in the agent wires them up.
+package route
+
+import "net/netip"
+
+// Prefix is one destination the Reconciler should ensure a route exists
+// for, such as the cluster's Service CIDR, Pod CIDR, or an operator
+// supplied additional CIDR.
+type Prefix struct {
+	Dst netip.Prefix
+	// Table is the routing table to install the route in. Zero means the
+	// kernel's main table.
+	Table int
+}
+
+// Update is a new desired state delivered to Run, e.g. from a Service CIDR
+// or Pod CIDR discovery loop in spiderpool-agent.
+type Update struct {
+	Desired []Prefix
+	Gateway netip.Addr
+}
+
+// Reconciler reconciles the kernel routing table against a desired set of
+// destination prefixes that all share one gateway.
+type Reconciler interface {
+	// Reconcile ensures exactly the routes in desired exist via gw,
+	// adding missing routes before removing ones that are no longer
+	// desired, so a shrinking prefix never leaves a window uncovered.
+	Reconcile(desired []Prefix, gw netip.Addr) error
+
+	// Run calls Reconcile for every Update received on updates, until
+	// stopCh is closed or updates is closed.
+	Run(stopCh <-chan struct{}, updates <-chan Update) error
+}